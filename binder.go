@@ -0,0 +1,32 @@
+package dotweb
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+//Binder 负责将请求数据解码到目标struct上，HttpServer默认使用newBinder创建的JSON实现
+type Binder interface {
+	Bind(req *http.Request, obj interface{}) error
+}
+
+//binder Binder的默认实现，按请求体JSON解码
+type binder struct {
+}
+
+func newBinder() Binder {
+	return &binder{}
+}
+
+func (b *binder) Bind(req *http.Request, obj interface{}) error {
+	if req.Body == nil {
+		return nil
+	}
+	defer req.Body.Close()
+	//GET/HEAD/DELETE等请求通常没有body，Decode在这种情况下返回io.EOF，不当作绑定失败处理
+	if err := json.NewDecoder(req.Body).Decode(obj); err != nil && err != io.EOF {
+		return err
+	}
+	return nil
+}
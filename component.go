@@ -0,0 +1,173 @@
+package dotweb
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+//ComponentOption 用于定制RegisterComponent的扫描与路由映射行为
+type ComponentOption func(*componentOptions)
+
+type componentOptions struct {
+	serviceName    string
+	methodNameFunc func(string) string
+}
+
+//WithServiceName 指定组件对外暴露的服务名，默认使用struct类型名
+func WithServiceName(name string) ComponentOption {
+	return func(opts *componentOptions) {
+		opts.serviceName = name
+	}
+}
+
+//WithMethodNameFunc 自定义方法名到HTTP方法+路径的映射规则，默认使用defaultMethodNameFunc
+func WithMethodNameFunc(f func(string) string) ComponentOption {
+	return func(opts *componentOptions) {
+		opts.methodNameFunc = f
+	}
+}
+
+//ComponentInitializer 组件在DotWeb启动时被调用
+type ComponentInitializer interface {
+	OnInit()
+}
+
+//ComponentShutdowner 组件在DotWeb停止时被调用
+type ComponentShutdowner interface {
+	OnShutdown()
+}
+
+var httpMethodPrefixes = []string{"Get", "Post", "Put", "Delete", "Head", "Options", "Patch"}
+
+var upperWordRegexp = regexp.MustCompile("[A-Z][a-z0-9]*")
+
+//defaultMethodNameFunc 将GetUser映射为"GET /user"，PostOrder映射为"POST /order"
+func defaultMethodNameFunc(methodName string) string {
+	for _, prefix := range httpMethodPrefixes {
+		if strings.HasPrefix(methodName, prefix) {
+			rest := strings.TrimPrefix(methodName, prefix)
+			return strings.ToUpper(prefix) + " " + toPath(rest)
+		}
+	}
+	return ""
+}
+
+//toPath 将驼峰命名的剩余部分转换为以/分隔的小写路径，如UserOrder -> /user/order
+func toPath(name string) string {
+	if name == "" {
+		return "/"
+	}
+	words := upperWordRegexp.FindAllString(name, -1)
+	path := ""
+	for _, word := range words {
+		path += "/" + strings.ToLower(word)
+	}
+	if path == "" {
+		return "/"
+	}
+	return path
+}
+
+//registerComponent 扫描comp的导出方法，将符合约定签名的方法注册为prefix下的路由
+func registerComponent(r *router, prefix string, comp interface{}, opts ...ComponentOption) error {
+	options := &componentOptions{
+		methodNameFunc: defaultMethodNameFunc,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+	if options.serviceName == "" {
+		options.serviceName = reflect.TypeOf(comp).String()
+	}
+
+	compValue := reflect.ValueOf(comp)
+	compType := compValue.Type()
+
+	for i := 0; i < compType.NumMethod(); i++ {
+		method := compType.Method(i)
+		mapping := options.methodNameFunc(method.Name)
+		if mapping == "" {
+			continue
+		}
+		parts := strings.SplitN(mapping, " ", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		httpMethod, path := parts[0], strings.TrimRight(prefix, "/")+parts[1]
+
+		handle, err := buildComponentHandle(r.server, compValue, method)
+		if err != nil {
+			return err
+		}
+		r.RegisterRoute(httpMethod, path, handle)
+	}
+
+	if initializer, ok := comp.(ComponentInitializer); ok {
+		r.server.DotApp.registerComponentInit(initializer.OnInit)
+	}
+	if shutdowner, ok := comp.(ComponentShutdowner); ok {
+		r.server.DotApp.registerComponentShutdown(shutdowner.OnShutdown)
+	}
+
+	return nil
+}
+
+var (
+	httpContextType = reflect.TypeOf((*HttpContext)(nil))
+	errorType       = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+//buildComponentHandle 将一个组件方法适配为HttpHandle
+//支持两种签名：func(*HttpContext)直接透传；func(*HttpContext, *ReqStruct) (Resp, error)由binder解码请求、由Renderer序列化返回值
+//method不满足以上任一签名时返回错误，而不是panic，避免一个命名恰好匹配HTTP前缀但签名写错的方法使RegisterComponent崩溃
+func buildComponentHandle(server *HttpServer, compValue reflect.Value, method reflect.Method) (HttpHandle, error) {
+	methodType := method.Type
+
+	//func(*HttpContext)
+	if methodType.NumIn() == 2 && methodType.NumOut() == 0 {
+		if methodType.In(1) != httpContextType {
+			return nil, fmt.Errorf("component: method %s must take *HttpContext as its only argument", method.Name)
+		}
+		return func(ctx *HttpContext) {
+			method.Func.Call([]reflect.Value{compValue, reflect.ValueOf(ctx)})
+		}, nil
+	}
+
+	//func(*HttpContext, *ReqStruct) (Resp, error)
+	if methodType.NumIn() != 3 || methodType.NumOut() != 2 {
+		return nil, fmt.Errorf("component: method %s must be either func(*HttpContext) or func(*HttpContext, *ReqStruct) (Resp, error)", method.Name)
+	}
+	if methodType.In(1) != httpContextType {
+		return nil, fmt.Errorf("component: method %s's first argument must be *HttpContext", method.Name)
+	}
+	reqType := methodType.In(2)
+	if reqType.Kind() != reflect.Ptr {
+		return nil, fmt.Errorf("component: method %s's second argument must be a pointer to a request struct", method.Name)
+	}
+	if !methodType.Out(1).Implements(errorType) {
+		return nil, fmt.Errorf("component: method %s's second return value must be error", method.Name)
+	}
+
+	return func(ctx *HttpContext) {
+		reqValue := reflect.New(reqType.Elem())
+		if err := server.Binder().Bind(ctx.Request, reqValue.Interface()); err != nil {
+			panic(err)
+		}
+
+		results := method.Func.Call([]reflect.Value{compValue, reflect.ValueOf(ctx), reqValue})
+		respValue, errValue := results[0], results[1]
+		if !errValue.IsNil() {
+			panic(errValue.Interface().(error))
+		}
+
+		renderer := server.Renderer()
+		if renderer == nil {
+			renderer = &jsonRenderer{}
+		}
+		if err := renderer.Render(ctx, respValue.Interface()); err != nil {
+			panic(err)
+		}
+	}, nil
+}
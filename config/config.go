@@ -0,0 +1,74 @@
+package config
+
+//ServerConfig HttpServer运行时使用的配置
+type ServerConfig struct {
+	//EnabledAutoHEAD 是否自动为GET路由注册HEAD方法
+	EnabledAutoHEAD bool
+	//Gzip gzip压缩相关配置，见GzipConfig
+	Gzip *GzipConfig
+}
+
+//NewServerConfig 创建一个默认的ServerConfig
+func NewServerConfig() *ServerConfig {
+	return &ServerConfig{
+		EnabledAutoHEAD: false,
+		Gzip:            NewGzipConfig(),
+	}
+}
+
+//GzipConfig gzip压缩的决策参数，由HttpServer在响应写出前使用（见(*HttpServer).flushResponse）
+type GzipConfig struct {
+	//Enabled 是否启用gzip压缩，默认false
+	Enabled bool
+	//MinLength 低于该字节数的响应不压缩，避免为小响应付出压缩开销，默认1024
+	MinLength int
+	//Level compress/gzip的压缩级别，1-9，默认9
+	Level int
+	//ContentTypes 允许压缩的Content-Type前缀列表，默认覆盖文本、json、javascript、xml、svg
+	ContentTypes []string
+}
+
+//NewGzipConfig 创建一个默认的GzipConfig
+func NewGzipConfig() *GzipConfig {
+	return &GzipConfig{
+		Enabled:   false,
+		MinLength: 1024,
+		Level:     9,
+		ContentTypes: []string{
+			"text/",
+			"application/json",
+			"application/javascript",
+			"application/xml",
+			"image/svg+xml",
+		},
+	}
+}
+
+//SessionConfig session相关配置，用于初始化session.StoreConfig
+type SessionConfig struct {
+	//EnabledSession 是否启用session
+	EnabledSession bool
+	//Timeout session的默认有效期，单位秒
+	Timeout int64
+	//SessionMode 使用的Store名称，对应session.Register注册的名称，如"runtime"、"redis"、"cookie"
+	SessionMode string
+	//ServerIP Store依赖的远程服务地址，如Redis地址
+	ServerIP string
+	//DBIndex Redis库索引
+	DBIndex int
+	//Password Redis连接密码
+	Password string
+	//KeyPrefix Redis下session key的前缀
+	KeyPrefix string
+	//HashKey Cookie Store用于AES-GCM加解密的密钥
+	HashKey string
+}
+
+//NewSessionConfig 创建一个默认的SessionConfig，默认不启用session
+func NewSessionConfig() *SessionConfig {
+	return &SessionConfig{
+		EnabledSession: false,
+		Timeout:        3600,
+		SessionMode:    "runtime",
+	}
+}
@@ -0,0 +1,183 @@
+package dotweb
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/devfeel/dotweb/routers"
+	"github.com/devfeel/dotweb/session"
+)
+
+//HttpContext 封装了一次请求处理过程中的所有状态，可通过sync.Pool复用
+type HttpContext struct {
+	Response    *Response
+	Request     *http.Request
+	Params      routers.Params
+	HttpServer  *HttpServer
+	SessionID   string
+	//Session 本次请求范围内的会话句柄，由HttpServer在EnabledSession时创建，见BeginSession/EndSession
+	Session     *session.Session
+	IsWebSocket bool
+	WebSocket   *WebSocket
+
+	isEnd bool
+
+	//sessionRelease 归还BeginSession期间持有的资源（如redis连接），在请求结束时调用一次
+	sessionRelease func()
+
+	//disableCompression 对应Route.DisableCompression，关闭本次响应的gzip压缩
+	disableCompression bool
+
+	ctx       context.Context
+	cancelCh  chan struct{}
+	cancelled int32
+	//active 记录当前仍在操作本次请求的goroutine数量，用于WithTimeout场景下
+	//原始goroutine与超时后仍在运行的用户handle goroutine之间，保证归还sync.Pool的动作只执行一次
+	active int32
+}
+
+//Reset 从sync.Pool取出后，用当前请求相关的对象重置状态，避免复用时带出上一次请求的数据
+func (ctx *HttpContext) Reset(res *Response, r *http.Request, server *HttpServer, params routers.Params) {
+	ctx.Response = res
+	ctx.Request = r
+	ctx.HttpServer = server
+	ctx.Params = params
+	ctx.SessionID = ""
+	ctx.Session = nil
+	ctx.sessionRelease = nil
+	ctx.IsWebSocket = false
+	ctx.WebSocket = nil
+	ctx.isEnd = false
+	ctx.disableCompression = false
+	if r != nil {
+		ctx.ctx = r.Context()
+	} else {
+		ctx.ctx = context.Background()
+	}
+	ctx.cancelCh = make(chan struct{})
+	ctx.cancelled = 0
+	ctx.active = 1
+}
+
+//Context 返回与当前请求关联的context.Context，默认等同于Request.Context()
+//WithTimeout中间件会替换为一个带超时的子context
+func (ctx *HttpContext) Context() context.Context {
+	return ctx.ctx
+}
+
+//setContext 替换当前请求关联的context.Context，供WithTimeout等中间件使用
+func (ctx *HttpContext) setContext(c context.Context) {
+	ctx.ctx = c
+}
+
+//Done 返回的channel会在请求被取消（超时或显式调用Cancel）时关闭
+//长时间运行的handle可以select该channel及时退出，避免协程泄漏
+func (ctx *HttpContext) Done() <-chan struct{} {
+	return ctx.cancelCh
+}
+
+//Cancel 显式取消当前请求，关闭Done返回的channel，多次调用是安全的
+func (ctx *HttpContext) Cancel() {
+	if atomic.CompareAndSwapInt32(&ctx.cancelled, 0, 1) {
+		close(ctx.cancelCh)
+	}
+}
+
+//beginGoroutine 标记又有一个goroutine开始操作本次请求的HttpContext，需要与finishGoroutine成对出现
+func (ctx *HttpContext) beginGoroutine() {
+	atomic.AddInt32(&ctx.active, 1)
+}
+
+//finishGoroutine 标记一个goroutine结束了对本次请求HttpContext的操作
+//当所有goroutine都结束后（计数归零），才真正把HttpContext和Response归还sync.Pool
+func (ctx *HttpContext) finishGoroutine() {
+	if atomic.AddInt32(&ctx.active, -1) == 0 {
+		ctx.HttpServer.releaseContext(ctx)
+	}
+}
+
+//beginSession 绑定SessionID到一次请求范围的session.Session，使本次请求内的所有session操作
+//（包括后续的Touch）共享同一个底层连接，而不是每次都单独向Store借用/归还
+func (ctx *HttpContext) beginSession(manager *session.SessionManager) {
+	ctx.Session, ctx.sessionRelease = manager.Begin(ctx.SessionID)
+}
+
+//endSession 归还beginSession期间持有的资源，必须在请求结束时调用一次
+func (ctx *HttpContext) endSession() {
+	if ctx.sessionRelease != nil {
+		ctx.sessionRelease()
+		ctx.sessionRelease = nil
+	}
+}
+
+//release 归还到sync.Pool前清理引用，避免内存泄漏
+func (ctx *HttpContext) release() {
+	ctx.Response = nil
+	ctx.Request = nil
+	ctx.WebSocket = nil
+	ctx.Session = nil
+}
+
+//SetHeader 设置响应header
+func (ctx *HttpContext) SetHeader(key, value string) {
+	ctx.Response.SetHeader(key, value)
+}
+
+//SetCookie 向响应中追加一个Set-Cookie
+func (ctx *HttpContext) SetCookie(cookie http.Cookie) {
+	ctx.Response.SetCookie(&cookie)
+}
+
+//Hijack 接管底层连接，用于需要直接操作TCP连接的场景
+func (ctx *HttpContext) Hijack() (net.Conn, error) {
+	hijacker, ok := ctx.Response.Writer().(http.Hijacker)
+	if !ok {
+		return nil, http.ErrNotSupported
+	}
+	conn, _, err := hijacker.Hijack()
+	return conn, err
+}
+
+//End 标记当前请求已结束，后续的用户handle将不再被调用
+func (ctx *HttpContext) End() {
+	ctx.isEnd = true
+}
+
+//IsEnd 返回当前请求是否已经结束
+func (ctx *HttpContext) IsEnd() bool {
+	return ctx.isEnd
+}
+
+//Url 返回当前请求的完整URL
+func (ctx *HttpContext) Url() string {
+	return ctx.Request.URL.String()
+}
+
+//RemoteIP 返回客户端IP，优先取X-Real-IP/X-Forwarded-For
+func (ctx *HttpContext) RemoteIP() string {
+	if ip := ctx.Request.Header.Get("X-Real-IP"); ip != "" {
+		return ip
+	}
+	if ip := ctx.Request.Header.Get("X-Forwarded-For"); ip != "" {
+		return strings.Split(ip, ",")[0]
+	}
+	host, _, err := net.SplitHostPort(ctx.Request.RemoteAddr)
+	if err != nil {
+		return ctx.Request.RemoteAddr
+	}
+	return host
+}
+
+//WriteString 向响应体写入字符串
+func (ctx *HttpContext) WriteString(content string) (int, error) {
+	return ctx.Response.Write([]byte(content))
+}
+
+//WriteJson 将data序列化为JSON并写入响应体
+func (ctx *HttpContext) WriteJson(data interface{}) error {
+	return json.NewEncoder(ctx.Response).Encode(data)
+}
@@ -0,0 +1,52 @@
+package dotweb
+
+import "net/http"
+
+//DotWeb 应用实例，持有HttpServer以及应用级别的模块、异常处理与维护页面
+type DotWeb struct {
+	HttpServer        *HttpServer
+	Modules           []*HttpModule
+	ExceptionHandler  func(ctx *HttpContext, err interface{})
+	OfflineServer     http.Handler
+	componentInits    []func()
+	componentShutdown []func()
+}
+
+//New 创建一个DotWeb实例
+func New() *DotWeb {
+	app := &DotWeb{
+		Modules: make([]*HttpModule, 0),
+	}
+	app.HttpServer = NewHttpServer()
+	app.HttpServer.setDotApp(app)
+	return app
+}
+
+//Use 注册一个HttpModule
+func (app *DotWeb) Use(module *HttpModule) {
+	app.Modules = append(app.Modules, module)
+}
+
+//registerComponentInit 记录一个组件的OnInit回调，在Start时统一调用
+func (app *DotWeb) registerComponentInit(f func()) {
+	app.componentInits = append(app.componentInits, f)
+}
+
+//registerComponentShutdown 记录一个组件的OnShutdown回调，在Stop时统一调用
+func (app *DotWeb) registerComponentShutdown(f func()) {
+	app.componentShutdown = append(app.componentShutdown, f)
+}
+
+//Start 启动应用，依次调用通过RegisterComponent注册的组件的OnInit钩子
+func (app *DotWeb) Start() {
+	for _, init := range app.componentInits {
+		init()
+	}
+}
+
+//Stop 停止应用，依次调用通过RegisterComponent注册的组件的OnShutdown钩子
+func (app *DotWeb) Stop() {
+	for _, shutdown := range app.componentShutdown {
+		shutdown()
+	}
+}
@@ -0,0 +1,160 @@
+package dotweb
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const gzipScheme = "gzip"
+const deflateScheme = "deflate"
+
+//compressWriter 是gzip.Writer与flate.Writer共有的方法子集，RegisterCompressor的factory需要返回这样一个实例
+//以便按压缩级别放入sync.Pool复用
+type compressWriter interface {
+	io.WriteCloser
+	Reset(w io.Writer)
+}
+
+//compressorFactory 按压缩级别创建一个compressWriter，用作各自sync.Pool的New函数
+type compressorFactory func(level int) (compressWriter, error)
+
+var (
+	compressorLock sync.RWMutex
+	compressors    = make(map[string]compressorFactory)
+)
+
+func init() {
+	//gzip、deflate都基于标准库实现，默认注册；br需要cgo或第三方brotli实现，这里不内置，
+	//有需要的调用方可以自行RegisterCompressor("br", ...)
+	RegisterCompressor(gzipScheme, func(level int) (compressWriter, error) {
+		return gzip.NewWriterLevel(nil, level)
+	})
+	RegisterCompressor(deflateScheme, func(level int) (compressWriter, error) {
+		return flate.NewWriter(nil, level)
+	})
+}
+
+//RegisterCompressor 注册一种Content-Encoding对应的压缩实现，name会被用于与客户端Accept-Encoding协商
+//以及写出时的Content-Encoding header，重复注册时会覆盖之前的实现
+func RegisterCompressor(name string, factory compressorFactory) {
+	compressorLock.Lock()
+	defer compressorLock.Unlock()
+	compressors[name] = factory
+}
+
+func getCompressorFactory(name string) (compressorFactory, bool) {
+	compressorLock.RLock()
+	defer compressorLock.RUnlock()
+	factory, ok := compressors[name]
+	return factory, ok
+}
+
+//compressWriterPools 按"encoding:level"复用对应的compressWriter，避免每次响应都重新分配
+var compressWriterPools sync.Map // map[string]*sync.Pool
+
+func getCompressWriterPool(name string, level int) *sync.Pool {
+	key := name + ":" + strconv.Itoa(level)
+	if p, ok := compressWriterPools.Load(key); ok {
+		return p.(*sync.Pool)
+	}
+	factory, _ := getCompressorFactory(name)
+	pool := &sync.Pool{
+		New: func() interface{} {
+			w, _ := factory(level)
+			return w
+		},
+	}
+	actual, _ := compressWriterPools.LoadOrStore(key, pool)
+	return actual.(*sync.Pool)
+}
+
+//flushResponse 把httpCtx.Response中缓冲的status/header/body一次性写出
+//是否压缩、用哪种编码在这里才决定，此时ResponseModifier已经执行完毕，既能看到最终的Content-Type与长度，
+//也保证rewrite响应内容的modifier看到的始终是未压缩的原始字节
+func (server *HttpServer) flushResponse(ctx *HttpContext) {
+	res := ctx.Response
+	status, _, body, streaming := res.snapshot()
+	if streaming {
+		return
+	}
+
+	if encoding, ok := server.negotiateCompression(ctx, body); ok {
+		server.writeCompressed(res, status, body, encoding)
+		return
+	}
+	res.flushToWire()
+}
+
+//negotiateCompression 依次校验：全局开关、路由级opt-out、响应体大小、Content-Type白名单，
+//最后按客户端Accept-Encoding中出现的顺序，选取第一个已通过RegisterCompressor注册的编码
+func (server *HttpServer) negotiateCompression(ctx *HttpContext, body []byte) (string, bool) {
+	cfg := server.ServerConfig.Gzip
+	if cfg == nil || !cfg.Enabled {
+		return "", false
+	}
+	if ctx.disableCompression {
+		return "", false
+	}
+	if len(body) < cfg.MinLength {
+		return "", false
+	}
+
+	contentType := ctx.Response.GetHeader(HeaderContentType)
+	if contentType == "" {
+		contentType = http.DetectContentType(body)
+	}
+	if !matchesContentType(contentType, cfg.ContentTypes) {
+		return "", false
+	}
+
+	return acceptedEncoding(ctx.Request)
+}
+
+//acceptedEncoding 解析请求的Accept-Encoding，按其中出现的顺序返回第一个已注册的压缩编码
+//"identity"与"*"不在此处处理，不压缩时调用方会退回未压缩输出，效果等价于不支持该编码
+func acceptedEncoding(r *http.Request) (string, bool) {
+	for _, enc := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name := strings.TrimSpace(strings.SplitN(enc, ";", 2)[0])
+		if name == "" {
+			continue
+		}
+		if _, ok := getCompressorFactory(name); ok {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+//matchesContentType 判断contentType是否匹配allowlist中的某一项（前缀匹配，如"text/"匹配"text/html; charset=utf-8"）
+func matchesContentType(contentType string, allowlist []string) bool {
+	for _, allowed := range allowlist {
+		if strings.HasPrefix(contentType, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+//writeCompressed 从sync.Pool取出encoding对应的compressWriter，压缩body后写出，用完归还池中供下次复用
+func (server *HttpServer) writeCompressed(res *Response, status int, body []byte, encoding string) {
+	res.mu.Lock()
+	defer res.mu.Unlock()
+
+	res.header.Set(HeaderContentEncoding, encoding)
+	res.header.Del("Content-Length")
+	writer := res.writer
+	copyHeader(writer.Header(), res.header)
+	writer.WriteHeader(status)
+
+	pool := getCompressWriterPool(encoding, server.ServerConfig.Gzip.Level)
+	cw := pool.Get().(compressWriter)
+	cw.Reset(writer)
+	cw.Write(body)
+	cw.Close()
+	pool.Put(cw)
+}
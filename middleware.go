@@ -0,0 +1,39 @@
+package dotweb
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+//WithTimeout 返回一个包装HttpHandle的中间件：用context.WithTimeout包装请求的Context，
+//在独立的goroutine中运行原handle，超时后直接写出504并调用httpCtx.End()，不再等待原handle结束
+//
+//原handle若耗时超过d仍会在后台继续运行，这与netstack的gonet适配器对socket deadline的处理方式一致：
+//deadline到达即不再等待，由调用方自行通过HttpContext.Done()感知取消并尽快退出，避免goroutine泄漏
+func WithTimeout(d time.Duration) func(HttpHandle) HttpHandle {
+	return func(handle HttpHandle) HttpHandle {
+		return func(ctx *HttpContext) {
+			timeoutCtx, cancel := context.WithTimeout(ctx.Context(), d)
+			defer cancel()
+			ctx.setContext(timeoutCtx)
+
+			done := make(chan struct{})
+			ctx.beginGoroutine()
+			go func() {
+				defer ctx.finishGoroutine()
+				defer close(done)
+				handle(ctx)
+			}()
+
+			select {
+			case <-done:
+			case <-timeoutCtx.Done():
+				ctx.Response.WriteHeader(http.StatusGatewayTimeout)
+				ctx.WriteString("request timeout")
+				ctx.End()
+				ctx.Cancel()
+			}
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package dotweb
+
+//Renderer 负责将handler返回的数据序列化写入响应，可通过HttpServer.SetRenderer替换为自定义实现
+type Renderer interface {
+	Render(ctx *HttpContext, data interface{}) error
+}
+
+//jsonRenderer 默认的Renderer实现，将返回值序列化为JSON
+type jsonRenderer struct {
+}
+
+func (r *jsonRenderer) Render(ctx *HttpContext, data interface{}) error {
+	ctx.SetHeader(HeaderContentType, "application/json")
+	return ctx.WriteJson(data)
+}
@@ -0,0 +1,146 @@
+package dotweb
+
+import (
+	"bytes"
+	"net/http"
+	"sync"
+)
+
+//Response 对http.ResponseWriter的包装
+//状态码、header与body默认先缓冲在内存中，等待ResponseModifier链执行完毕后再一次性写出
+//流式handler可以调用Flush退出缓冲模式，之后的Write直接穿透到底层连接
+//mu保护所有内部状态，因为WithTimeout场景下原handle可能仍在后台goroutine里读写同一个Response
+type Response struct {
+	mu        sync.Mutex
+	writer    http.ResponseWriter
+	header    http.Header
+	body      *bytes.Buffer
+	Status    int
+	Size      int64
+	streaming bool
+}
+
+//Reset 从sync.Pool取出后，用当前请求的ResponseWriter重置状态
+func (r *Response) Reset(w http.ResponseWriter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.writer = w
+	r.header = make(http.Header)
+	if r.body == nil {
+		r.body = new(bytes.Buffer)
+	} else {
+		r.body.Reset()
+	}
+	r.Status = http.StatusOK
+	r.Size = 0
+	r.streaming = false
+}
+
+//Writer 返回最终写出响应时使用的底层http.ResponseWriter
+func (r *Response) Writer() http.ResponseWriter {
+	return r.writer
+}
+
+//Header 返回响应header，满足http.ResponseWriter接口；并发场景下请改用SetHeader/GetHeader
+func (r *Response) Header() http.Header {
+	return r.header
+}
+
+//SetHeader 加锁设置响应header，是WithTimeout场景下替代Header().Set(...)的并发安全方式
+func (r *Response) SetHeader(key, value string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.header.Set(key, value)
+}
+
+//GetHeader 加锁读取响应header
+func (r *Response) GetHeader(key string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.header.Get(key)
+}
+
+//SetCookie 加锁追加一个Set-Cookie，等价于net/http.SetCookie(r, cookie)但不绕过mu
+func (r *Response) SetCookie(cookie *http.Cookie) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if v := cookie.String(); v != "" {
+		r.header.Add("Set-Cookie", v)
+	}
+}
+
+//WriteHeader 记录状态码，实际写出延迟到flushToWire（或Flush退出缓冲后立即写出）
+func (r *Response) WriteHeader(code int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Status = code
+	if r.streaming {
+		r.writer.WriteHeader(code)
+	}
+}
+
+//Write 缓冲模式下写入内存body；Flush过的streaming模式下直接写入底层连接
+func (r *Response) Write(data []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Size += int64(len(data))
+	if r.streaming {
+		return r.writer.Write(data)
+	}
+	return r.body.Write(data)
+}
+
+//Flush 供流式handler（如SSE、长轮询）调用，放弃缓冲、直接写出header并切换为透传模式
+//调用后ModifyResponse不再能修改本次响应，因为字节已经在路上
+func (r *Response) Flush() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.streaming {
+		return
+	}
+	r.streaming = true
+	copyHeader(r.writer.Header(), r.header)
+	r.writer.WriteHeader(r.Status)
+	if r.body.Len() > 0 {
+		r.writer.Write(r.body.Bytes())
+		r.body.Reset()
+	}
+	if flusher, ok := r.writer.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+//flushToWire 在ResponseModifier链执行完毕后调用，把最终的header/status/body一次性写出
+//streaming模式下响应已经写出过了，这里不再重复处理
+func (r *Response) flushToWire() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.streaming {
+		return
+	}
+	copyHeader(r.writer.Header(), r.header)
+	r.writer.WriteHeader(r.Status)
+	r.writer.Write(r.body.Bytes())
+}
+
+//IsStreaming 加锁返回本次响应是否已经被Flush切换为流式（透传）模式
+func (r *Response) IsStreaming() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.streaming
+}
+
+//snapshot 加锁读取gzip.go决定是否压缩所需的status/header/body/streaming
+func (r *Response) snapshot() (status int, header http.Header, body []byte, streaming bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.Status, r.header, r.body.Bytes(), r.streaming
+}
+
+func copyHeader(dst, src http.Header) {
+	for key, values := range src {
+		for _, value := range values {
+			dst.Add(key, value)
+		}
+	}
+}
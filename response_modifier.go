@@ -0,0 +1,33 @@
+package dotweb
+
+//ResponseModifier 在用户handle与HttpModule.OnEndRequest之后、响应字节真正写出之前，对响应做最后的改写
+//此时Response.Status/Header()/body均仍在内存缓冲区中，可以安全地整体替换，例如把5xx降级成更友好的400、
+//补充链路追踪header、或者重写返回内容中的链接
+type ResponseModifier interface {
+	ModifyResponse(ctx *HttpContext) error
+}
+
+//ResponseModifierFunc 允许将普通函数适配为ResponseModifier
+type ResponseModifierFunc func(ctx *HttpContext) error
+
+func (f ResponseModifierFunc) ModifyResponse(ctx *HttpContext) error {
+	return f(ctx)
+}
+
+//UseResponseModifier 向HttpServer注册一个或多个ResponseModifier，按注册顺序依次执行
+func (server *HttpServer) UseResponseModifier(modifiers ...ResponseModifier) {
+	server.responseModifiers = append(server.responseModifiers, modifiers...)
+}
+
+//runResponseModifiers 依次执行已注册的ResponseModifier，Response此时仍处于缓冲状态
+//若handler调用过Response.Flush()进入了流式模式，字节已经写到连接上，不再执行修改
+func (server *HttpServer) runResponseModifiers(ctx *HttpContext) {
+	if ctx.Response.IsStreaming() {
+		return
+	}
+	for _, modifier := range server.responseModifiers {
+		if err := modifier.ModifyResponse(ctx); err != nil {
+			logger.Log("ResponseModifier error: "+err.Error(), LogTarget_HttpServer, LogLevel_Error)
+		}
+	}
+}
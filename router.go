@@ -0,0 +1,90 @@
+package dotweb
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/devfeel/dotweb/routers"
+)
+
+//Router 路由管理器，负责注册路由与请求分发
+type Router interface {
+	//ServeHTTP 按照请求method、path匹配已注册路由并调用对应handle
+	ServeHTTP(w http.ResponseWriter, req *http.Request)
+	//RegisterRoute 注册一条method+path对应的handle，返回该路由的*Route以便继续设置选项
+	RegisterRoute(method string, path string, handle HttpHandle) *Route
+	//RegisterComponent 以反射方式扫描comp的导出方法并批量注册为prefix下的路由，参见ComponentOption
+	RegisterComponent(prefix string, comp interface{}, opts ...ComponentOption) error
+	//RegisterWebSocket 注册一个基于HttpServer.SetWebSocketUpgrader的websocket路由
+	//未配置WebSocketUpgrader时请继续使用旧的http.Handle+wrapWebSocketHandle方式
+	RegisterWebSocket(path string, handle HttpHandle)
+}
+
+//Route 一条已注册路由的信息，RegisterRoute的返回值
+type Route struct {
+	Method string
+	Path   string
+	Handle HttpHandle
+
+	compressionDisabled bool
+}
+
+//DisableCompression 关闭本条路由的gzip压缩，适用于已经是压缩格式（图片、视频）或流式输出的路由
+//返回Route本身以便链式调用，如 router.RegisterRoute("GET", "/avatar", handle).DisableCompression()
+func (route *Route) DisableCompression() *Route {
+	route.compressionDisabled = true
+	return route
+}
+
+//router Router的默认实现
+type router struct {
+	server   *HttpServer
+	routes   map[string]*Route
+	wsRoutes map[string]HttpHandle
+}
+
+//NewRouter 创建Router默认实现的实例
+func NewRouter(server *HttpServer) Router {
+	return &router{
+		server:   server,
+		routes:   make(map[string]*Route),
+		wsRoutes: make(map[string]HttpHandle),
+	}
+}
+
+func routeKey(method, path string) string {
+	return strings.ToUpper(method) + " " + path
+}
+
+func (r *router) RegisterRoute(method string, path string, handle HttpHandle) *Route {
+	route := &Route{
+		Method: strings.ToUpper(method),
+		Path:   path,
+		Handle: handle,
+	}
+	r.routes[routeKey(method, path)] = route
+	return route
+}
+
+func (r *router) RegisterComponent(prefix string, comp interface{}, opts ...ComponentOption) error {
+	return registerComponent(r, prefix, comp, opts...)
+}
+
+func (r *router) RegisterWebSocket(path string, handle HttpHandle) {
+	r.wsRoutes[path] = handle
+}
+
+func (r *router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if handle, ok := r.wsRoutes[req.URL.Path]; ok && r.server.wsUpgrader != nil {
+		r.server.wrapWebSocketHandleV2(handle)(w, req, routers.Params{})
+		return
+	}
+
+	route, ok := r.routes[routeKey(req.Method, req.URL.Path)]
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+	handle := r.server.wrapRouterHandle(route.Handle, false, route.compressionDisabled)
+	handle(w, req, routers.Params{})
+}
@@ -0,0 +1,25 @@
+package routers
+
+import "net/http"
+
+//Param 单个路由参数
+type Param struct {
+	Key   string
+	Value string
+}
+
+//Params 一次请求匹配到的全部路由参数
+type Params []Param
+
+//ByName 根据参数名获取路由参数值，不存在时返回空字符串
+func (params Params) ByName(name string) string {
+	for _, p := range params {
+		if p.Key == name {
+			return p.Value
+		}
+	}
+	return ""
+}
+
+//Handle 路由下挂载的处理函数，携带当前请求匹配到的路由参数
+type Handle func(http.ResponseWriter, *http.Request, Params)
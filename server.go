@@ -12,19 +12,12 @@ import (
 	"sync"
 	"time"
 
-	"compress/gzip"
 	"github.com/devfeel/dotweb/config"
 	"github.com/devfeel/dotweb/routers"
 	"golang.org/x/net/websocket"
-	"io"
 	"net/url"
 )
 
-const (
-	DefaultGzipLevel = 9
-	gzipScheme       = "gzip"
-)
-
 type (
 	//HttpModule定义
 	HttpModule struct {
@@ -36,16 +29,18 @@ type (
 
 	//HttpServer定义
 	HttpServer struct {
-		router         Router
-		DotApp         *DotWeb
-		sessionManager *session.SessionManager
-		lock_session   *sync.RWMutex
-		pool           *pool
-		ServerConfig   *config.ServerConfig
-		SessionConfig  *config.SessionConfig
-		binder         Binder
-		render         Renderer
-		offline        bool
+		router            Router
+		DotApp            *DotWeb
+		sessionManager    *session.SessionManager
+		lock_session      *sync.RWMutex
+		pool              *pool
+		ServerConfig      *config.ServerConfig
+		SessionConfig     *config.SessionConfig
+		binder            Binder
+		render            Renderer
+		offline           bool
+		responseModifiers []ResponseModifier
+		wsUpgrader        *WebSocketUpgrader
 	}
 
 	//pool定义
@@ -87,7 +82,9 @@ func NewHttpServer() *HttpServer {
 //ServeHTTP make sure request can be handled correctly
 func (server *HttpServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	//针对websocket与调试信息特殊处理
-	if checkIsWebSocketRequest(req) {
+	//已配置WebSocketUpgrader时，握手与后续路由交给普通的Router流程（由wrapWebSocketHandleV2完成升级），
+	//未配置时回退到遗留的x/net/websocket + DefaultServeMux实现，保持向后兼容
+	if checkIsWebSocketRequest(req) && server.wsUpgrader == nil {
 		http.DefaultServeMux.ServeHTTP(w, req)
 	} else {
 		//设置header信息
@@ -117,19 +114,28 @@ func (server *HttpServer) SetSessionConfig(storeConfig *session.StoreConfig) {
 	server.SessionConfig.Timeout = storeConfig.Maxlifetime
 	server.SessionConfig.SessionMode = storeConfig.StoreName
 	server.SessionConfig.ServerIP = storeConfig.ServerIP
+	server.SessionConfig.DBIndex = storeConfig.DBIndex
+	server.SessionConfig.Password = storeConfig.Password
+	server.SessionConfig.KeyPrefix = storeConfig.KeyPrefix
+	server.SessionConfig.HashKey = storeConfig.HashKey
 }
 
 //init session manager
+//根据SessionConfig.SessionMode查找已注册的session.Store工厂（见session.Register），而不是固定使用内存Store
 func (server *HttpServer) InitSessionManager() {
 	storeConfig := new(session.StoreConfig)
 	storeConfig.Maxlifetime = server.SessionConfig.Timeout
 	storeConfig.StoreName = server.SessionConfig.SessionMode
 	storeConfig.ServerIP = server.SessionConfig.ServerIP
+	storeConfig.DBIndex = server.SessionConfig.DBIndex
+	storeConfig.Password = server.SessionConfig.Password
+	storeConfig.KeyPrefix = server.SessionConfig.KeyPrefix
+	storeConfig.HashKey = server.SessionConfig.HashKey
 
 	if server.sessionManager == nil {
 		//设置Session
 		server.lock_session.Lock()
-		if manager, err := session.NewDefaultSessionManager(storeConfig); err != nil {
+		if manager, err := session.NewSessionManager(storeConfig); err != nil {
 			//panic error with create session manager
 			panic(err.Error())
 		} else {
@@ -139,6 +145,32 @@ func (server *HttpServer) InitSessionManager() {
 	}
 }
 
+//releaseContext 把HttpContext及其Response归还到各自的sync.Pool
+//只应在确认没有任何goroutine还在使用该HttpContext时调用，见HttpContext.finishGoroutine
+func (server *HttpServer) releaseContext(ctx *HttpContext) {
+	res := ctx.Response
+	ctx.release()
+	if res != nil {
+		server.pool.response.Put(res)
+	}
+	server.pool.context.Put(ctx)
+}
+
+//writeSessionCookie 向客户端下发session cookie
+//绝大多数Store下cookie值就是session id本身；使用cookie store时，CookieValue会重新加密当前session的全部数据
+func (server *HttpServer) writeSessionCookie(httpCtx *HttpContext) {
+	value, err := server.sessionManager.CookieValue(httpCtx.SessionID)
+	if err != nil {
+		return
+	}
+	cookie := http.Cookie{
+		Name:  server.sessionManager.CookieName,
+		Value: url.QueryEscape(value),
+		Path:  "/",
+	}
+	httpCtx.SetCookie(cookie)
+}
+
 /*
 * 关联当前HttpServer实例对应的DotServer实例
  */
@@ -179,6 +211,18 @@ func (server *HttpServer) SetEnabledAutoHEAD(autoHEAD bool) {
 	server.ServerConfig.EnabledAutoHEAD = autoHEAD
 }
 
+//SetGzipConfig 替换当前的gzip压缩配置，见config.GzipConfig
+func (server *HttpServer) SetGzipConfig(gzipConfig *config.GzipConfig) {
+	server.ServerConfig.Gzip = gzipConfig
+}
+
+//SetWebSocketUpgrader 配置基于gorilla/websocket的升级器，配置后websocket请求交给Router分发，
+//经RegisterWebSocket注册的路由走wrapWebSocketHandleV2，其余路径按普通路由匹配
+//不配置则继续使用wrapWebSocketHandle的遗留实现
+func (server *HttpServer) SetWebSocketUpgrader(upgrader *WebSocketUpgrader) {
+	server.wsUpgrader = upgrader
+}
+
 type LogJson struct {
 	RequestUrl string
 	HttpHeader string
@@ -186,24 +230,17 @@ type LogJson struct {
 }
 
 //wrap HttpHandle to httprouter.Handle
-func (server *HttpServer) wrapRouterHandle(handle HttpHandle, isHijack bool) routers.Handle {
+func (server *HttpServer) wrapRouterHandle(handle HttpHandle, isHijack bool, disableCompression bool) routers.Handle {
 	return func(w http.ResponseWriter, r *http.Request, params routers.Params) {
 		//get from pool
 		res := server.pool.response.Get().(*Response)
 		res.Reset(w)
 		httpCtx := server.pool.context.Get().(*HttpContext)
 		httpCtx.Reset(res, r, server, params)
+		httpCtx.disableCompression = disableCompression
 
-		//gzip
-		if server.ServerConfig.EnabledGzip {
-			gw, err := gzip.NewWriterLevel(w, DefaultGzipLevel)
-			if err != nil {
-				panic("use gzip error -> " + err.Error())
-			}
-			grw := &gzipResponseWriter{Writer: gw, ResponseWriter: w}
-			res.Reset(grw)
-			httpCtx.SetHeader(HeaderContentEncoding, gzipScheme)
-		}
+		//注意：gzip的应用时机被推迟到ResponseModifier链执行完毕之后（见flushResponse），
+		//这样rewrite响应内容的modifier看到的始终是未压缩的原始字节
 		//增加状态计数
 		GlobalState.AddRequestCount(1)
 
@@ -216,14 +253,9 @@ func (server *HttpServer) wrapRouterHandle(handle HttpHandle, isHijack bool) rou
 				httpCtx.SessionID = sessionId
 			} else {
 				httpCtx.SessionID = server.GetSessionManager().NewSessionID()
-				cookie := http.Cookie{
-					Name:  server.sessionManager.CookieName,
-					Value: url.QueryEscape(httpCtx.SessionID),
-					Path:  "/",
-				}
-				httpCtx.SetCookie(cookie)
+				server.writeSessionCookie(httpCtx)
 			}
-
+			httpCtx.beginSession(server.GetSessionManager())
 		}
 
 		//hijack处理
@@ -232,8 +264,9 @@ func (server *HttpServer) wrapRouterHandle(handle HttpHandle, isHijack bool) rou
 			if hijack_err != nil {
 				//输出内容
 				httpCtx.Response.WriteHeader(http.StatusInternalServerError)
-				httpCtx.Response.Header().Set(HeaderContentType, CharsetUTF8)
+				httpCtx.Response.SetHeader(HeaderContentType, CharsetUTF8)
 				httpCtx.WriteString(hijack_err.Error())
+				httpCtx.Response.flushToWire()
 				return
 			}
 		}
@@ -266,16 +299,20 @@ func (server *HttpServer) wrapRouterHandle(handle HttpHandle, isHijack bool) rou
 			//HttpServer Logging
 			logger.Log(httpCtx.Url()+" "+logContext(httpCtx, timetaken), LogTarget_HttpRequest, LogLevel_Debug)
 
-			if server.ServerConfig.EnabledGzip {
-				var w io.Writer
-				w = res.Writer().(*gzipResponseWriter).Writer
-				w.(*gzip.Writer).Close()
+			//延长本次请求使用的session的有效期，并在使用cookie store时刷新下发的cookie内容
+			if server.SessionConfig.EnabledSession && httpCtx.SessionID != "" {
+				httpCtx.Session.Touch()
+				server.writeSessionCookie(httpCtx)
+				httpCtx.endSession()
 			}
-			// Return to pool
-			server.pool.response.Put(res)
-			//release context
-			httpCtx.release()
-			server.pool.context.Put(httpCtx)
+
+			//执行ResponseModifier链，随后把最终的响应一次性写出（含gzip）
+			server.runResponseModifiers(httpCtx)
+			server.flushResponse(httpCtx)
+
+			//归还到sync.Pool，若WithTimeout中仍有用户handle的goroutine未结束，
+			//这里不会真正归还，交由那个goroutine结束时自己触发（见HttpContext.finishGoroutine）
+			httpCtx.finishGoroutine()
 		}()
 
 		//处理前置Module集合
@@ -360,6 +397,42 @@ func (server *HttpServer) wrapWebSocketHandle(handle HttpHandle) websocket.Handl
 	}
 }
 
+//wrap HttpHandle to routers.Handle using the gorilla/websocket-based WebSocketUpgrader
+//与wrapWebSocketHandle不同，这里走普通的Router分发，握手发生在用户handle被调用之前，
+//协商出的子协议通过httpCtx.WebSocket.Subprotocol暴露给handle，使同一个URL可以按子协议分发到不同的应用层协议
+func (server *HttpServer) wrapWebSocketHandleV2(handle HttpHandle) routers.Handle {
+	return func(w http.ResponseWriter, r *http.Request, params routers.Params) {
+		httpCtx := server.pool.context.Get().(*HttpContext)
+		httpCtx.Reset(nil, r, server, params)
+		httpCtx.IsWebSocket = true
+
+		startTime := time.Now()
+		defer func() {
+			var errmsg string
+			if err := recover(); err != nil {
+				errmsg = exception.CatchError("httpserver::WebsocketHandleV2", LogTarget_HttpServer, err)
+				logger.Log(errmsg, LogTarget_HttpServer, LogLevel_Error)
+				GlobalState.AddErrorCount(1)
+			}
+			timetaken := int64(time.Now().Sub(startTime) / time.Millisecond)
+			logger.Log(httpCtx.Url()+" "+logContext(httpCtx, timetaken), LogTarget_HttpRequest, LogLevel_Debug)
+			server.pool.context.Put(httpCtx)
+		}()
+
+		ws, err := server.wsUpgrader.upgrade(w, r)
+		if err != nil {
+			logger.Log("websocket upgrade error -> "+err.Error(), LogTarget_HttpServer, LogLevel_Error)
+			return
+		}
+		httpCtx.WebSocket = ws
+		defer ws.GorillaConn.Close()
+
+		handle(httpCtx)
+
+		GlobalState.AddRequestCount(1)
+	}
+}
+
 //get default log string
 func logContext(ctx *HttpContext, timetaken int64) string {
 	var reqbytelen, resbytelen, method, proto, status, userip string
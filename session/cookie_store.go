@@ -0,0 +1,192 @@
+package session
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/gob"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+func init() {
+	Register("cookie", newCookieStore)
+}
+
+var errShortCiphertext = errors.New("session: cookie value shorter than gcm nonce")
+
+//cookiePayload 是实际被加密后写入cookie的内容
+//StableID是解密后用来给内存缓存做key的稳定标识，不随每次重新加密而改变（不同于下发给客户端的密文本身）
+type cookiePayload struct {
+	StableID string
+	Values   map[string]interface{}
+}
+
+//cookieEntry 一个session在内存中的缓存项，只在maxlifetime内存活，由GC回收，避免随请求数量无限增长
+type cookieEntry struct {
+	values  map[string]interface{}
+	expires time.Time
+}
+
+//cookieStore 无服务端持久化状态的Store实现，session数据被AES-GCM加密后保存在cookie值中
+//内存中仅按StableID缓存“本次生命周期内已经读取/修改过”的session，同runtimeStore一样靠GC按过期时间回收，
+//而不是随着每次请求下发的密文（会变化）无限堆积
+type cookieStore struct {
+	block       cipher.Block
+	lock        sync.Mutex
+	cache       map[string]*cookieEntry
+	maxlifetime int64
+}
+
+func newCookieStore(config *StoreConfig) (Store, error) {
+	block, err := aes.NewCipher([]byte(config.HashKey))
+	if err != nil {
+		return nil, err
+	}
+	return &cookieStore{
+		block:       block,
+		cache:       make(map[string]*cookieEntry),
+		maxlifetime: config.Maxlifetime,
+	}, nil
+}
+
+//entry 返回stableId对应的缓存项，不存在时创建一个空的，有效期为maxlifetime
+func (store *cookieStore) entry(stableId string) *cookieEntry {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	entry, ok := store.cache[stableId]
+	if !ok {
+		entry = &cookieEntry{
+			values:  make(map[string]interface{}),
+			expires: time.Now().Add(time.Duration(store.maxlifetime) * time.Second),
+		}
+		store.cache[stableId] = entry
+	}
+	return entry
+}
+
+func (store *cookieStore) Get(stableId, key string) (interface{}, error) {
+	entry := store.entry(stableId)
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	return entry.values[key], nil
+}
+
+func (store *cookieStore) Set(stableId, key string, value interface{}) error {
+	entry := store.entry(stableId)
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	entry.values[key] = value
+	return nil
+}
+
+func (store *cookieStore) Delete(stableId, key string) error {
+	entry := store.entry(stableId)
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	delete(entry.values, key)
+	return nil
+}
+
+//Exists 判断stableId当前是否存在于缓存中，即本次进程生命周期内是否已经被读取或修改过
+func (store *cookieStore) Exists(stableId string) bool {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	_, ok := store.cache[stableId]
+	return ok
+}
+
+//Touch 续期stableId对应缓存项的过期时间，不存在时视为新建
+func (store *cookieStore) Touch(stableId string, ttl time.Duration) error {
+	entry := store.entry(stableId)
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	entry.expires = time.Now().Add(ttl)
+	return nil
+}
+
+//GC 回收已过期的缓存项，与runtimeStore的策略一致，保证cache不会随请求量无限增长
+func (store *cookieStore) GC() {
+	now := time.Now()
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	for id, entry := range store.cache {
+		if now.After(entry.expires) {
+			delete(store.cache, id)
+		}
+	}
+}
+
+//DecodeID 解密客户端带来的cookie值，取出其中的StableID并用其中的数据预热缓存
+//解密失败（被篡改、HashKey变更等）时返回错误，调用方（SessionManager.GetClientSessionID）据此当作客户端没有有效session处理
+func (store *cookieStore) DecodeID(cookieValue string) (string, error) {
+	payload, err := store.decrypt(cookieValue)
+	if err != nil {
+		return "", err
+	}
+
+	store.lock.Lock()
+	store.cache[payload.StableID] = &cookieEntry{
+		values:  payload.Values,
+		expires: time.Now().Add(time.Duration(store.maxlifetime) * time.Second),
+	}
+	store.lock.Unlock()
+
+	return payload.StableID, nil
+}
+
+//Encode 将stableId对应的当前缓存数据重新加密，得到应下发给客户端的新cookie值
+//HttpServer在wrapRouterHandle结束时会对cookie store特殊处理，调用Encode替换Cookie.Value
+func (store *cookieStore) Encode(stableId string) (string, error) {
+	entry := store.entry(stableId)
+
+	store.lock.Lock()
+	payload := cookiePayload{StableID: stableId, Values: entry.values}
+	store.lock.Unlock()
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(payload); err != nil {
+		return "", err
+	}
+
+	gcm, err := cipher.NewGCM(store.block)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, buf.Bytes(), nil)
+	return base64.URLEncoding.EncodeToString(sealed), nil
+}
+
+//decrypt 解密cookie值，还原出cookiePayload
+func (store *cookieStore) decrypt(cookieValue string) (cookiePayload, error) {
+	var payload cookiePayload
+
+	raw, err := base64.URLEncoding.DecodeString(cookieValue)
+	if err != nil {
+		return payload, err
+	}
+	gcm, err := cipher.NewGCM(store.block)
+	if err != nil {
+		return payload, err
+	}
+	if len(raw) < gcm.NonceSize() {
+		return payload, errShortCiphertext
+	}
+	nonce, ciphertext := raw[:gcm.NonceSize()], raw[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return payload, err
+	}
+	if err := gob.NewDecoder(bytes.NewReader(plain)).Decode(&payload); err != nil {
+		return payload, err
+	}
+	return payload, nil
+}
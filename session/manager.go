@@ -0,0 +1,151 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const DefaultCookieName = "dotwebsessionid"
+
+//SessionManager 管理当前应用使用的Store，并提供session id的生成与读取
+type SessionManager struct {
+	CookieName  string
+	store       Store
+	maxlifetime int64
+}
+
+//NewSessionManager 根据StoreConfig中的StoreName查找已注册的Store并创建SessionManager
+//旧版本的NewDefaultSessionManager固定使用内存Store，现在按需查找注册表，未指定StoreName时退回"runtime"
+func NewSessionManager(config *StoreConfig) (*SessionManager, error) {
+	if config.StoreName == "" {
+		config.StoreName = "runtime"
+	}
+	store, err := NewStore(config)
+	if err != nil {
+		return nil, err
+	}
+	manager := &SessionManager{
+		CookieName:  DefaultCookieName,
+		store:       store,
+		maxlifetime: config.Maxlifetime,
+	}
+	go manager.gcLoop()
+	return manager, nil
+}
+
+//gcLoop 定时触发Store.GC，周期与session有效期保持一致
+func (manager *SessionManager) gcLoop() {
+	for {
+		time.Sleep(time.Duration(manager.maxlifetime) * time.Second)
+		manager.store.GC()
+	}
+}
+
+//NewSessionID 生成一个新的session id
+func (manager *SessionManager) NewSessionID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+//GetClientSessionID 从请求cookie中读取客户端已有的session id
+//cookie store等下发值与内部id不一致的Store会实现IDDecoder，此时需要先还原出稳定的内部id，
+//否则每次请求都会因为cookie值变化（重新加密）而被当成一个新的session
+func (manager *SessionManager) GetClientSessionID(req *http.Request) (string, error) {
+	cookie, err := req.Cookie(manager.CookieName)
+	if err != nil || cookie.Value == "" {
+		return "", err
+	}
+	//HttpServer下发cookie时做了url.QueryEscape，这里先还原
+	value, err := url.QueryUnescape(cookie.Value)
+	if err != nil {
+		return "", err
+	}
+	if decoder, ok := manager.store.(IDDecoder); ok {
+		return decoder.DecodeID(value)
+	}
+	return value, nil
+}
+
+//Get 获取指定session、指定key的值
+func (manager *SessionManager) Get(sessionId, key string) (interface{}, error) {
+	return manager.store.Get(sessionId, key)
+}
+
+//Set 设置指定session、指定key的值
+func (manager *SessionManager) Set(sessionId, key string, value interface{}) error {
+	return manager.store.Set(sessionId, key, value)
+}
+
+//Delete 删除指定session下指定key的值
+func (manager *SessionManager) Delete(sessionId, key string) error {
+	return manager.store.Delete(sessionId, key)
+}
+
+//Exists 判断指定session是否存在
+func (manager *SessionManager) Exists(sessionId string) bool {
+	return manager.store.Exists(sessionId)
+}
+
+//Touch 续期指定session的过期时间，在请求结束时调用以延长session有效期
+func (manager *SessionManager) Touch(sessionId string) error {
+	return manager.store.Touch(sessionId, time.Duration(manager.maxlifetime)*time.Second)
+}
+
+//Session 绑定某一次请求、某一个session id的句柄，由SessionManager.Begin创建
+//对实现了ConnScoped的Store（如redisStore），该请求期间的所有操作共享同一条底层连接
+type Session struct {
+	manager   *SessionManager
+	sessionId string
+	store     Store
+}
+
+//Begin 为sessionId开启一次请求范围的会话句柄
+//返回的release函数必须在请求结束时调用一次，以归还Begin期间持有的底层连接等资源
+func (manager *SessionManager) Begin(sessionId string) (*Session, func()) {
+	store := manager.store
+	release := func() {}
+	if scoped, ok := manager.store.(ConnScoped); ok {
+		store, release = scoped.Begin()
+	}
+	return &Session{manager: manager, sessionId: sessionId, store: store}, release
+}
+
+//Get 获取当前session下指定key的值
+func (s *Session) Get(key string) (interface{}, error) {
+	return s.store.Get(s.sessionId, key)
+}
+
+//Set 设置当前session下指定key的值
+func (s *Session) Set(key string, value interface{}) error {
+	return s.store.Set(s.sessionId, key, value)
+}
+
+//Delete 删除当前session下指定key的值
+func (s *Session) Delete(key string) error {
+	return s.store.Delete(s.sessionId, key)
+}
+
+//Exists 判断当前session是否存在
+func (s *Session) Exists() bool {
+	return s.store.Exists(s.sessionId)
+}
+
+//Touch 续期当前session的过期时间
+func (s *Session) Touch() error {
+	return s.store.Touch(s.sessionId, time.Duration(s.manager.maxlifetime)*time.Second)
+}
+
+//CookieValue 得到应下发给客户端的cookie值
+//对绝大多数Store而言就是sessionId本身；对cookie store而言，数据即存于cookie，需要重新加密当前内容
+func (manager *SessionManager) CookieValue(sessionId string) (string, error) {
+	if encoder, ok := manager.store.(interface {
+		Encode(sessionId string) (string, error)
+	}); ok {
+		return encoder.Encode(sessionId)
+	}
+	return sessionId, nil
+}
@@ -0,0 +1,159 @@
+package session
+
+import (
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+func init() {
+	Register("redis", newRedisStore)
+}
+
+//redisStore 基于Redis的Store实现，使用连接池支撑并发请求，同一个session的读写操作共用从池中取出的连接
+type redisStore struct {
+	pool      *redis.Pool
+	keyPrefix string
+	maxlifetime int64
+}
+
+func newRedisStore(config *StoreConfig) (Store, error) {
+	pool := &redis.Pool{
+		MaxIdle:     16,
+		IdleTimeout: 240 * time.Second,
+		Dial: func() (redis.Conn, error) {
+			conn, err := redis.Dial("tcp", config.ServerIP)
+			if err != nil {
+				return nil, err
+			}
+			if config.Password != "" {
+				if _, err := conn.Do("AUTH", config.Password); err != nil {
+					conn.Close()
+					return nil, err
+				}
+			}
+			if _, err := conn.Do("SELECT", config.DBIndex); err != nil {
+				conn.Close()
+				return nil, err
+			}
+			return conn, nil
+		},
+		TestOnBorrow: func(conn redis.Conn, t time.Time) error {
+			_, err := conn.Do("PING")
+			return err
+		},
+	}
+
+	store := &redisStore{
+		pool:        pool,
+		keyPrefix:   config.KeyPrefix,
+		maxlifetime: config.Maxlifetime,
+	}
+	return store, nil
+}
+
+//sessionKey 给session id加上业务前缀，避免与同一个Redis实例下的其它key冲突
+func (store *redisStore) sessionKey(sessionId string) string {
+	return store.keyPrefix + sessionId
+}
+
+func (store *redisStore) Get(sessionId, key string) (interface{}, error) {
+	conn := store.pool.Get()
+	defer conn.Close()
+	return conn.Do("HGET", store.sessionKey(sessionId), key)
+}
+
+func (store *redisStore) Set(sessionId, key string, value interface{}) error {
+	conn := store.pool.Get()
+	defer conn.Close()
+	if _, err := conn.Do("HSET", store.sessionKey(sessionId), key, value); err != nil {
+		return err
+	}
+	_, err := conn.Do("EXPIRE", store.sessionKey(sessionId), store.maxlifetime)
+	return err
+}
+
+func (store *redisStore) Delete(sessionId, key string) error {
+	conn := store.pool.Get()
+	defer conn.Close()
+	_, err := conn.Do("HDEL", store.sessionKey(sessionId), key)
+	return err
+}
+
+func (store *redisStore) Exists(sessionId string) bool {
+	conn := store.pool.Get()
+	defer conn.Close()
+	exists, err := redis.Bool(conn.Do("EXISTS", store.sessionKey(sessionId)))
+	if err != nil {
+		return false
+	}
+	return exists
+}
+
+func (store *redisStore) Touch(sessionId string, ttl time.Duration) error {
+	conn := store.pool.Get()
+	defer conn.Close()
+	_, err := conn.Do("EXPIRE", store.sessionKey(sessionId), int64(ttl/time.Second))
+	return err
+}
+
+//GC Redis依靠自身的EXPIRE机制回收过期key，这里无需额外处理
+func (store *redisStore) GC() {
+}
+
+//Begin 从连接池中取出一条连接，返回一个绑定该连接的Store，使同一次请求内的多次Get/Set/Delete/Exists/Touch
+//共用一条连接而不是每次调用都单独borrow/close；release在请求结束时归还连接
+func (store *redisStore) Begin() (Store, func()) {
+	conn := store.pool.Get()
+	scoped := &redisConnStore{
+		conn:        conn,
+		keyPrefix:   store.keyPrefix,
+		maxlifetime: store.maxlifetime,
+	}
+	return scoped, func() { conn.Close() }
+}
+
+//redisConnStore 绑定单条Redis连接的Store实现，由redisStore.Begin创建，生命周期仅限一次请求
+type redisConnStore struct {
+	conn        redis.Conn
+	keyPrefix   string
+	maxlifetime int64
+}
+
+func (store *redisConnStore) sessionKey(sessionId string) string {
+	return store.keyPrefix + sessionId
+}
+
+func (store *redisConnStore) Get(sessionId, key string) (interface{}, error) {
+	return store.conn.Do("HGET", store.sessionKey(sessionId), key)
+}
+
+func (store *redisConnStore) Set(sessionId, key string, value interface{}) error {
+	if _, err := store.conn.Do("HSET", store.sessionKey(sessionId), key, value); err != nil {
+		return err
+	}
+	_, err := store.conn.Do("EXPIRE", store.sessionKey(sessionId), store.maxlifetime)
+	return err
+}
+
+func (store *redisConnStore) Delete(sessionId, key string) error {
+	_, err := store.conn.Do("HDEL", store.sessionKey(sessionId), key)
+	return err
+}
+
+func (store *redisConnStore) Exists(sessionId string) bool {
+	exists, err := redis.Bool(store.conn.Do("EXISTS", store.sessionKey(sessionId)))
+	if err != nil {
+		return false
+	}
+	return exists
+}
+
+func (store *redisConnStore) Touch(sessionId string, ttl time.Duration) error {
+	_, err := store.conn.Do("EXPIRE", store.sessionKey(sessionId), int64(ttl/time.Second))
+	return err
+}
+
+//GC redisConnStore的生命周期仅限一次请求，过期回收仍由底层redisStore.GC（即Redis自身EXPIRE）负责
+func (store *redisConnStore) GC() {
+}
@@ -0,0 +1,95 @@
+package session
+
+import (
+	"sync"
+	"time"
+)
+
+func init() {
+	Register("runtime", newRuntimeStore)
+}
+
+//runtimeItem 单个session在内存中的数据与过期时间
+type runtimeItem struct {
+	values  map[string]interface{}
+	expires time.Time
+}
+
+//runtimeStore 基于进程内存的Store实现，是框架默认的Store，不具备跨进程共享能力
+type runtimeStore struct {
+	lock        sync.RWMutex
+	items       map[string]*runtimeItem
+	maxlifetime int64
+}
+
+func newRuntimeStore(config *StoreConfig) (Store, error) {
+	return &runtimeStore{
+		items:       make(map[string]*runtimeItem),
+		maxlifetime: config.Maxlifetime,
+	}, nil
+}
+
+func (store *runtimeStore) Get(sessionId, key string) (interface{}, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	item, ok := store.items[sessionId]
+	if !ok {
+		return nil, nil
+	}
+	return item.values[key], nil
+}
+
+func (store *runtimeStore) Set(sessionId, key string, value interface{}) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	item, ok := store.items[sessionId]
+	if !ok {
+		item = &runtimeItem{
+			values:  make(map[string]interface{}),
+			expires: time.Now().Add(time.Duration(store.maxlifetime) * time.Second),
+		}
+		store.items[sessionId] = item
+	}
+	item.values[key] = value
+	return nil
+}
+
+func (store *runtimeStore) Delete(sessionId, key string) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	item, ok := store.items[sessionId]
+	if !ok {
+		return nil
+	}
+	delete(item.values, key)
+	return nil
+}
+
+func (store *runtimeStore) Exists(sessionId string) bool {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	_, ok := store.items[sessionId]
+	return ok
+}
+
+func (store *runtimeStore) Touch(sessionId string, ttl time.Duration) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	item, ok := store.items[sessionId]
+	if !ok {
+		return nil
+	}
+	item.expires = time.Now().Add(ttl)
+	return nil
+}
+
+func (store *runtimeStore) GC() {
+	now := time.Now()
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	for id, item := range store.items {
+		if now.After(item.expires) {
+			delete(store.items, id)
+		}
+	}
+}
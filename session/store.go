@@ -0,0 +1,80 @@
+package session
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Store 定义session数据的存储行为，具体实现可以是内存、Redis、Cookie等
+// 每个Store实现需要保证并发安全
+type Store interface {
+	//Get 获取指定session、指定key的值
+	Get(sessionId, key string) (interface{}, error)
+	//Set 设置指定session、指定key的值
+	Set(sessionId, key string, value interface{}) error
+	//Delete 删除指定session下指定key的值
+	Delete(sessionId, key string) error
+	//Exists 判断指定session是否存在
+	Exists(sessionId string) bool
+	//Touch 续期指定session的过期时间
+	Touch(sessionId string, ttl time.Duration) error
+	//GC 清理已过期的session，由SessionManager定时调用
+	GC()
+}
+
+// IDDecoder 由对外暴露的sessionId与内部稳定id不一致的Store实现，例如cookie store：
+// 下发给客户端的是每次都会变化的加密串，但GC/缓存需要一个不变的内部id，DecodeID负责把前者还原成后者
+type IDDecoder interface {
+	DecodeID(externalID string) (stableID string, err error)
+}
+
+// ConnScoped 由可以把底层连接在一次请求内多次Store调用之间共享的Store实现，目前redisStore实现了它
+// Begin返回一个绑定同一条连接的Store，以及请求结束时归还该连接的release函数
+type ConnScoped interface {
+	Begin() (scoped Store, release func())
+}
+
+// StoreConfig 用于初始化Store的配置信息
+type StoreConfig struct {
+	//StoreName 对应已注册的Store工厂名称，如"runtime"、"redis"、"cookie"
+	StoreName string
+	//Maxlifetime session的默认有效期
+	Maxlifetime int64
+	//ServerIP Store依赖的远程服务地址，如Redis地址
+	ServerIP string
+	//DBIndex Redis库索引
+	DBIndex int
+	//Password Redis连接密码
+	Password string
+	//KeyPrefix Redis下session key的前缀，用于多业务共享一个Redis实例时做隔离
+	KeyPrefix string
+	//HashKey Cookie Store用于AES-GCM加解密的密钥，长度必须是16/24/32字节
+	HashKey string
+}
+
+// StoreFactory 根据StoreConfig创建一个Store实例
+type StoreFactory func(config *StoreConfig) (Store, error)
+
+var (
+	storeFactories = make(map[string]StoreFactory)
+	storeLock      sync.RWMutex
+)
+
+// Register 注册一个Store工厂，name重复注册时会覆盖之前的实现
+func Register(name string, factory StoreFactory) {
+	storeLock.Lock()
+	defer storeLock.Unlock()
+	storeFactories[name] = factory
+}
+
+// NewStore 根据StoreConfig中的StoreName查找已注册的工厂并创建Store
+func NewStore(config *StoreConfig) (Store, error) {
+	storeLock.RLock()
+	factory, exists := storeFactories[config.StoreName]
+	storeLock.RUnlock()
+	if !exists {
+		return nil, errors.New("session: unknown store " + config.StoreName)
+	}
+	return factory(config)
+}
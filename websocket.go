@@ -0,0 +1,100 @@
+package dotweb
+
+import (
+	"net/http"
+	"time"
+
+	gorillaws "github.com/gorilla/websocket"
+	"golang.org/x/net/websocket"
+)
+
+//WebSocket 封装了一次websocket会话
+//Conn是golang.org/x/net/websocket的旧版实现留下的连接，仅在未配置WebSocketUpgrader时使用
+//GorillaConn/Subprotocol是新的gorilla/websocket实现使用的字段，见WebSocketUpgrader
+type WebSocket struct {
+	Conn        *websocket.Conn
+	GorillaConn *gorillaws.Conn
+	Subprotocol string
+}
+
+//WebSocketUpgrader 基于github.com/gorilla/websocket的升级器，相比旧版x/net/websocket实现
+//支持子协议协商、读写超时与心跳保活、消息大小限制与来源校验
+type WebSocketUpgrader struct {
+	//Subprotocols 按优先级排列的可选子协议，与客户端Sec-WebSocket-Protocol协商取交集中的第一个
+	Subprotocols []string
+	//ReadBufferSize/WriteBufferSize 底层连接的读写缓冲区大小
+	ReadBufferSize, WriteBufferSize int
+	//HandshakeTimeout 握手超时时间
+	HandshakeTimeout time.Duration
+	//ReadDeadline 握手完成后以及每次收到pong时，把读超时设为当前时间加该值；0表示不设置
+	//WriteDeadline 仅在keepAlive发送每个ping之前设置写超时，handle自己的写操作不会单独设置；0表示不设置
+	ReadDeadline, WriteDeadline time.Duration
+	//PingInterval 服务端主动发送ping的间隔，0表示不发送心跳
+	PingInterval time.Duration
+	//MaxMessageSize 允许接收的最大消息体大小，0表示不限制
+	MaxMessageSize int64
+	//CheckOrigin 来源校验策略，为nil时使用gorilla/websocket的默认策略（仅允许同源）
+	CheckOrigin func(r *http.Request) bool
+}
+
+//NewWebSocketUpgrader 创建一个WebSocketUpgrader，使用完成后需要配置到HttpServer.SetWebSocketUpgrader
+func NewWebSocketUpgrader() *WebSocketUpgrader {
+	return &WebSocketUpgrader{
+		ReadBufferSize:  4096,
+		WriteBufferSize: 4096,
+		PingInterval:    54 * time.Second,
+	}
+}
+
+//upgrade 完成握手并返回已协商好子协议、设置好读写超时的WebSocket
+//每次调用都构造一个本地的gorillaws.Upgrader，而不是复用/修改某个共享字段，避免并发的握手请求互相踩字段
+func (u *WebSocketUpgrader) upgrade(w http.ResponseWriter, r *http.Request) (*WebSocket, error) {
+	upgrader := gorillaws.Upgrader{
+		ReadBufferSize:   u.ReadBufferSize,
+		WriteBufferSize:  u.WriteBufferSize,
+		HandshakeTimeout: u.HandshakeTimeout,
+		Subprotocols:     u.Subprotocols,
+		CheckOrigin:      u.CheckOrigin,
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.MaxMessageSize > 0 {
+		conn.SetReadLimit(u.MaxMessageSize)
+	}
+	if u.ReadDeadline > 0 {
+		conn.SetReadDeadline(time.Now().Add(u.ReadDeadline))
+	}
+	conn.SetPongHandler(func(string) error {
+		if u.ReadDeadline > 0 {
+			conn.SetReadDeadline(time.Now().Add(u.ReadDeadline))
+		}
+		return nil
+	})
+
+	if u.PingInterval > 0 {
+		go u.keepAlive(conn)
+	}
+
+	return &WebSocket{
+		GorillaConn: conn,
+		Subprotocol: conn.Subprotocol(),
+	}, nil
+}
+
+//keepAlive 周期性向客户端发送ping，收到对应的pong会在SetPongHandler中重置读超时
+func (u *WebSocketUpgrader) keepAlive(conn *gorillaws.Conn) {
+	ticker := time.NewTicker(u.PingInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if u.WriteDeadline > 0 {
+			conn.SetWriteDeadline(time.Now().Add(u.WriteDeadline))
+		}
+		if err := conn.WriteMessage(gorillaws.PingMessage, nil); err != nil {
+			return
+		}
+	}
+}